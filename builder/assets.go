@@ -0,0 +1,127 @@
+package builder
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileMap maps a logical asset path (e.g. "/static/app.css") to the
+// content-hashed path it was fingerprinted to (e.g.
+// "/static/app.3f9a2b1c.css"), so HTML output and the serve command can
+// both resolve fingerprinted assets.
+type FileMap map[string]string
+
+const fileMapName = "filemap.json"
+
+// FingerprintAssets copies staticDir into outputDir/static, renaming
+// each file to include a short hash of its contents, and returns a
+// FileMap so callers can rewrite references and set long-lived cache
+// headers on the fingerprinted names.
+func FingerprintAssets(staticDir, outputDir string) (FileMap, error) {
+	fileMap := make(FileMap)
+
+	if _, err := os.Stat(staticDir); os.IsNotExist(err) {
+		return fileMap, nil
+	}
+
+	destDir := filepath.Join(outputDir, "static")
+
+	err := filepath.WalkDir(staticDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(staticDir, path)
+		if err != nil {
+			return err
+		}
+
+		sum := sha256.Sum256(data)
+		shortHash := hex.EncodeToString(sum[:])[:8]
+
+		ext := filepath.Ext(rel)
+		base := strings.TrimSuffix(rel, ext)
+		fingerprinted := fmt.Sprintf("%s.%s%s", base, shortHash, ext)
+
+		destPath := filepath.Join(destDir, fingerprinted)
+		if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(destPath, data, 0644); err != nil {
+			return err
+		}
+
+		fileMap["/static/"+filepath.ToSlash(rel)] = "/static/" + filepath.ToSlash(fingerprinted)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return fileMap, nil
+}
+
+// RewriteAssetURLs replaces every logical asset reference in html with
+// its fingerprinted path from fileMap.
+func RewriteAssetURLs(html string, fileMap FileMap) string {
+	for logical, fingerprinted := range fileMap {
+		html = strings.ReplaceAll(html, logical, fingerprinted)
+	}
+	return html
+}
+
+// SaveFileMap persists fileMap to outputDir so a separately running
+// process (the serve command) can load it without re-running the build.
+func SaveFileMap(outputDir string, fileMap FileMap) error {
+	data, err := json.MarshalIndent(fileMap, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(outputDir, fileMapName), data, 0644)
+}
+
+// LoadFileMap reads a FileMap previously written by SaveFileMap. A
+// missing file (no build has written one yet) returns an empty FileMap
+// rather than an error.
+func LoadFileMap(outputDir string) (FileMap, error) {
+	data, err := os.ReadFile(filepath.Join(outputDir, fileMapName))
+	if os.IsNotExist(err) {
+		return FileMap{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var fileMap FileMap
+	if err := json.Unmarshal(data, &fileMap); err != nil {
+		return nil, err
+	}
+
+	return fileMap, nil
+}
+
+// IsFingerprinted reports whether path (as served, e.g. "/static/app.3f9a2b1c.css")
+// is one of the content-hashed names in fileMap.
+func (f FileMap) IsFingerprinted(path string) bool {
+	for _, fingerprinted := range f {
+		if fingerprinted == path {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,318 @@
+// Package builder contains the static site generation pipeline shared by
+// the one-shot ssg binary and the CLI's dev server, so both build the same
+// output from the same posts and templates.
+package builder
+
+import (
+	"bufio"
+	"fmt"
+	"html/template"
+	"os"
+	"strings"
+
+	"github.com/gomarkdown/markdown"
+	"gopkg.in/yaml.v2"
+
+	"github.com/Mr-Destructive/gh-host/config"
+)
+
+type Post struct {
+	Title   string   `yaml:"title"`
+	Date    string   `yaml:"date"`
+	Tags    []string `yaml:"tags"`
+	Content string
+	Slug    string
+	BaseURL string
+	Feeds   FeedLinks
+}
+
+// FeedLinks carries the site-wide feed URLs into templates so the
+// rendered `<head>` can point back at the Atom feed that covers it.
+type FeedLinks struct {
+	Atom string
+	RSS  string
+}
+
+// Build reads every post in contentDir, renders the post/index/tag pages
+// and the atom/rss/sitemap feeds into outputDir, and returns the posts
+// that were rendered.
+func Build(contentDir, outputDir, baseURL string, cfg config.Config) ([]Post, error) {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(contentDir, 0755); err != nil {
+		return nil, err
+	}
+
+	posts, err := ReadPosts(contentDir, baseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	feeds := FeedLinks{
+		Atom: baseURL + "/atom.xml",
+		RSS:  baseURL + "/feed.xml",
+	}
+	for i := range posts {
+		posts[i].Feeds = feeds
+	}
+
+	if err := GeneratePosts(posts, outputDir); err != nil {
+		return nil, err
+	}
+
+	if err := GenerateIndex(posts, outputDir, baseURL, feeds); err != nil {
+		return nil, err
+	}
+
+	if err := GenerateTags(posts, outputDir, baseURL, feeds); err != nil {
+		return nil, err
+	}
+
+	if err := GenerateFeeds(posts, outputDir, baseURL, cfg); err != nil {
+		return nil, err
+	}
+
+	if err := processAssets(outputDir, cfg.CSP); err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+// BuildIncremental re-renders only the post identified by changedSlug
+// instead of every post page, then regenerates the index, tag and feed
+// pages (which are cheap once posts are parsed, since they only
+// aggregate the already-rendered Post values) plus the fingerprinted
+// asset/CSP pass. It's used by the dev server so a single edited post
+// doesn't force a full-site re-render. If changedSlug no longer exists
+// (the post was deleted), its stale output page is removed instead.
+func BuildIncremental(contentDir, outputDir, baseURL string, cfg config.Config, changedSlug string) ([]Post, error) {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, err
+	}
+
+	posts, err := ReadPosts(contentDir, baseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	feeds := FeedLinks{
+		Atom: baseURL + "/atom.xml",
+		RSS:  baseURL + "/feed.xml",
+	}
+	for i := range posts {
+		posts[i].Feeds = feeds
+	}
+
+	found := false
+	for _, post := range posts {
+		if post.Slug != changedSlug {
+			continue
+		}
+		found = true
+		if err := GeneratePost(post, outputDir); err != nil {
+			return nil, err
+		}
+	}
+	if !found && changedSlug != "" {
+		os.Remove(fmt.Sprintf("%s/%s.html", outputDir, changedSlug))
+	}
+
+	if err := GenerateIndex(posts, outputDir, baseURL, feeds); err != nil {
+		return nil, err
+	}
+
+	if err := GenerateTags(posts, outputDir, baseURL, feeds); err != nil {
+		return nil, err
+	}
+
+	if err := GenerateFeeds(posts, outputDir, baseURL, cfg); err != nil {
+		return nil, err
+	}
+
+	if err := processAssets(outputDir, cfg.CSP); err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+func ReadPosts(dir string, baseURL string) ([]Post, error) {
+	var posts []Post
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		if !file.IsDir() && strings.HasSuffix(file.Name(), ".md") {
+			post, err := readPost(fmt.Sprintf("%s/%s", dir, file.Name()), baseURL)
+			if err != nil {
+				return nil, err
+			}
+			posts = append(posts, post)
+		}
+	}
+
+	return posts, nil
+}
+
+func readPost(fileName string, baseURL string) (Post, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return Post{}, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	var frontmatter []string
+	var content []string
+	inFrontmatter := false
+	count := 0
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "---") {
+			count++
+			if count == 1 {
+				inFrontmatter = true
+				continue
+			}
+			if count == 2 {
+				inFrontmatter = false
+				continue
+			}
+		}
+
+		if inFrontmatter {
+			frontmatter = append(frontmatter, line)
+		} else {
+			content = append(content, line)
+		}
+	}
+
+	var post Post
+	err = yaml.Unmarshal([]byte(strings.Join(frontmatter, "\n")), &post)
+	if err != nil {
+		return Post{}, err
+	}
+
+	post.Content = string(markdown.ToHTML([]byte(strings.Join(content, "\n")), nil, nil))
+	post.Slug = strings.TrimSuffix(fileName, ".md")
+	post.Slug = strings.TrimPrefix(post.Slug, "content/posts/")
+	post.BaseURL = baseURL
+
+	return post, nil
+}
+
+func GeneratePosts(posts []Post, outputDir string) error {
+	tmpl, err := template.ParseFiles("templates/layout.html", "templates/post.html")
+	if err != nil {
+		return err
+	}
+
+	for _, post := range posts {
+		if err := generatePost(tmpl, post, outputDir); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GeneratePost renders a single post's HTML page, for callers that only
+// need to re-render one post instead of the whole site.
+func GeneratePost(post Post, outputDir string) error {
+	tmpl, err := template.ParseFiles("templates/layout.html", "templates/post.html")
+	if err != nil {
+		return err
+	}
+
+	return generatePost(tmpl, post, outputDir)
+}
+
+func generatePost(tmpl *template.Template, post Post, outputDir string) error {
+	file, err := os.Create(fmt.Sprintf("%s/%s.html", outputDir, post.Slug))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return tmpl.Execute(file, post)
+}
+
+func GenerateIndex(posts []Post, outputDir string, baseURL string, feeds FeedLinks) error {
+	tmpl, err := template.ParseFiles("templates/layout.html", "templates/index.html")
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(fmt.Sprintf("%s/index.html", outputDir))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	data := struct {
+		Posts   []Post
+		BaseURL string
+		Feeds   FeedLinks
+	}{
+		Posts:   posts,
+		BaseURL: baseURL,
+		Feeds:   feeds,
+	}
+
+	err = tmpl.Execute(file, data)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func GenerateTags(posts []Post, outputDir string, baseURL string, feeds FeedLinks) error {
+	tmpl, err := template.ParseFiles("templates/layout.html", "templates/tag.html")
+	if err != nil {
+		return err
+	}
+
+	tags := make(map[string][]Post)
+	for _, post := range posts {
+		for _, tag := range post.Tags {
+			tags[tag] = append(tags[tag], post)
+		}
+	}
+
+	for tag, posts := range tags {
+		file, err := os.Create(fmt.Sprintf("%s/tag-%s.html", outputDir, tag))
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		data := struct {
+			Tag     string
+			Posts   []Post
+			BaseURL string
+			Feeds   FeedLinks
+		}{
+			Tag:     tag,
+			Posts:   posts,
+			BaseURL: baseURL,
+			Feeds: FeedLinks{
+				Atom: fmt.Sprintf("%s/tag-%s.xml", baseURL, tag),
+				RSS:  feeds.RSS,
+			},
+		}
+
+		err = tmpl.Execute(file, data)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
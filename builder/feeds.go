@@ -0,0 +1,280 @@
+package builder
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Mr-Destructive/gh-host/config"
+)
+
+const postDateLayout = "2006-01-02"
+
+// feedXSL is a minimal stylesheet so Atom/RSS feeds render as a readable
+// page instead of raw XML when opened directly in a browser.
+const feedXSL = `<?xml version="1.0" encoding="UTF-8"?>
+<xsl:stylesheet version="1.0" xmlns:xsl="http://www.w3.org/1999/XSL/Transform">
+  <xsl:output method="html" encoding="UTF-8"/>
+  <xsl:template match="/">
+    <html>
+      <head><title>Feed</title></head>
+      <body>
+        <p>This is a feed. Subscribe to it with your favorite feed reader.</p>
+      </body>
+    </html>
+  </xsl:template>
+</xsl:stylesheet>
+`
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	Xmlns   string      `xml:"xmlns,attr"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Author  *atomAuthor `xml:"author,omitempty"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Title     string      `xml:"title"`
+	ID        string      `xml:"id"`
+	Updated   string      `xml:"updated"`
+	Published string      `xml:"published"`
+	Links     []atomLink  `xml:"link"`
+	Content   atomContent `xml:"content"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title       string    `xml:"title"`
+	Link        string    `xml:"link"`
+	Description string    `xml:"description"`
+	Items       []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	PubDate     string `xml:"pubDate"`
+	Description string `xml:"description"`
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// GenerateFeeds writes the site-wide atom.xml and feed.xml, a per-tag
+// atom feed for every tag, and a sitemap.xml covering the index, posts
+// and tag pages.
+func GenerateFeeds(posts []Post, outputDir string, baseURL string, cfg config.Config) error {
+	title := cfg.Title
+	if title == "" {
+		title = "Blog"
+	}
+
+	if err := writeXSL(outputDir); err != nil {
+		return err
+	}
+
+	if err := writeAtom(posts, outputDir, "atom.xml", baseURL, baseURL, baseURL+"/atom.xml", tagURI(cfg, "root"), title, cfg); err != nil {
+		return err
+	}
+
+	if err := writeRSS(posts, outputDir, baseURL, title); err != nil {
+		return err
+	}
+
+	if err := writeSitemap(posts, outputDir, baseURL); err != nil {
+		return err
+	}
+
+	tags := make(map[string][]Post)
+	for _, post := range posts {
+		for _, tag := range post.Tags {
+			tags[tag] = append(tags[tag], post)
+		}
+	}
+
+	for tag, tagPosts := range tags {
+		fileName := fmt.Sprintf("tag-%s.xml", tag)
+		self := fmt.Sprintf("%s/%s", baseURL, fileName)
+		alternate := fmt.Sprintf("%s/tag-%s.html", baseURL, tag)
+		if err := writeAtom(tagPosts, outputDir, fileName, baseURL, alternate, self, tagURI(cfg, "tag-"+tag), title+": "+tag, cfg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeXSL(outputDir string) error {
+	return os.WriteFile(fmt.Sprintf("%s/feed.xsl", outputDir), []byte(feedXSL), 0644)
+}
+
+func writeAtom(posts []Post, outputDir, fileName, baseURL, alternateURL, selfURL, feedID, title string, cfg config.Config) error {
+	entries := make([]atomEntry, 0, len(posts))
+	updated := time.Time{}
+
+	for _, post := range posts {
+		postTime := parsePostDate(post.Date)
+		if postTime.After(updated) {
+			updated = postTime
+		}
+
+		entries = append(entries, atomEntry{
+			Title:     post.Title,
+			ID:        tagURI(cfg, "post-"+post.Slug),
+			Updated:   postTime.Format(time.RFC3339),
+			Published: postTime.Format(time.RFC3339),
+			Links: []atomLink{
+				{Href: fmt.Sprintf("%s/%s.html", baseURL, post.Slug), Rel: "alternate"},
+			},
+			Content: atomContent{Type: "html", Body: post.Content},
+		})
+	}
+
+	if updated.IsZero() {
+		updated = parsePostDate(cfg.DomainStartDate)
+	}
+
+	feed := atomFeed{
+		Xmlns:   "http://www.w3.org/2005/Atom",
+		Title:   title,
+		ID:      feedID,
+		Updated: updated.Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: alternateURL, Rel: "alternate"},
+			{Href: selfURL, Rel: "self", Type: "application/atom+xml"},
+		},
+		Entries: entries,
+	}
+	if cfg.Author != "" {
+		feed.Author = &atomAuthor{Name: cfg.Author}
+	}
+
+	return writeXML(fmt.Sprintf("%s/%s", outputDir, fileName), feed)
+}
+
+func writeRSS(posts []Post, outputDir, baseURL, title string) error {
+	items := make([]rssItem, 0, len(posts))
+	for _, post := range posts {
+		link := fmt.Sprintf("%s/%s.html", baseURL, post.Slug)
+		items = append(items, rssItem{
+			Title:       post.Title,
+			Link:        link,
+			GUID:        link,
+			PubDate:     parsePostDate(post.Date).Format(time.RFC1123Z),
+			Description: post.Content,
+		})
+	}
+
+	feed := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:       title,
+			Link:        baseURL,
+			Description: title,
+			Items:       items,
+		},
+	}
+
+	return writeXML(fmt.Sprintf("%s/feed.xml", outputDir), feed)
+}
+
+func writeSitemap(posts []Post, outputDir, baseURL string) error {
+	urls := []sitemapURL{{Loc: baseURL + "/"}}
+
+	tags := make(map[string]struct{})
+	for _, post := range posts {
+		urls = append(urls, sitemapURL{
+			Loc:     fmt.Sprintf("%s/%s.html", baseURL, post.Slug),
+			LastMod: parsePostDate(post.Date).Format(postDateLayout),
+		})
+		for _, tag := range post.Tags {
+			tags[tag] = struct{}{}
+		}
+	}
+
+	for tag := range tags {
+		urls = append(urls, sitemapURL{Loc: fmt.Sprintf("%s/tag-%s.html", baseURL, tag)})
+	}
+
+	set := sitemapURLSet{
+		Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9",
+		URLs:  urls,
+	}
+
+	return writeXML(fmt.Sprintf("%s/sitemap.xml", outputDir), set)
+}
+
+func writeXML(path string, v any) error {
+	out, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	header := []byte(xml.Header)
+	if _, isSitemap := v.(sitemapURLSet); !isSitemap {
+		header = append(header, []byte(`<?xml-stylesheet type="text/xsl" href="/feed.xsl"?>`+"\n")...)
+	}
+
+	return os.WriteFile(path, append(header, out...), 0644)
+}
+
+// tagURI builds a stable `tag:` URI per RFC 4151, using the configured
+// domain and the date it started minting these identifiers so entry IDs
+// never change even if the post's URL does.
+func tagURI(cfg config.Config, id string) string {
+	domain := cfg.Domain
+	if domain == "" {
+		domain = "example.com"
+	}
+
+	startDate := cfg.DomainStartDate
+	if startDate == "" {
+		startDate = "1970-01-01"
+	}
+
+	return fmt.Sprintf("tag:%s,%s:%s", domain, startDate, id)
+}
+
+func parsePostDate(date string) time.Time {
+	if t, err := time.Parse(time.RFC3339, date); err == nil {
+		return t
+	}
+	if t, err := time.Parse(postDateLayout, date); err == nil {
+		return t
+	}
+	return time.Time{}
+}
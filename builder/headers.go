@@ -0,0 +1,81 @@
+package builder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Mr-Destructive/gh-host/config"
+)
+
+// processAssets fingerprints static/, rewrites every rendered HTML page
+// to reference the fingerprinted paths, computes each page's CSP, and
+// writes outputDir/_headers plus a persisted FileMap for the serve
+// command to pick up the cache headers without rebuilding.
+func processAssets(outputDir string, cspCfg config.CSPConfig) error {
+	fileMap, err := FingerprintAssets("static", outputDir)
+	if err != nil {
+		return err
+	}
+
+	cspByPage := make(map[string]string)
+
+	err = filepath.WalkDir(outputDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".html") {
+			return err
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		html := RewriteAssetURLs(string(data), fileMap)
+		if err := os.WriteFile(path, []byte(html), 0644); err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(outputDir, path)
+		if err != nil {
+			return err
+		}
+		cspByPage["/"+filepath.ToSlash(rel)] = ContentSecurityPolicy(html, cspCfg)
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := SaveFileMap(outputDir, fileMap); err != nil {
+		return err
+	}
+
+	return writeHeadersFile(outputDir, cspByPage)
+}
+
+// writeHeadersFile emits a Netlify-style _headers file: long-lived
+// immutable caching for fingerprinted static assets, and a
+// Content-Security-Policy per rendered page.
+func writeHeadersFile(outputDir string, cspByPage map[string]string) error {
+	var b strings.Builder
+
+	fmt.Fprintln(&b, "/static/*")
+	fmt.Fprintln(&b, "  Cache-Control: public, max-age=31536000, immutable")
+
+	pages := make([]string, 0, len(cspByPage))
+	for page := range cspByPage {
+		pages = append(pages, page)
+	}
+	sort.Strings(pages)
+
+	for _, page := range pages {
+		fmt.Fprintln(&b)
+		fmt.Fprintln(&b, page)
+		fmt.Fprintf(&b, "  Content-Security-Policy: %s\n", cspByPage[page])
+	}
+
+	return os.WriteFile(filepath.Join(outputDir, "_headers"), []byte(b.String()), 0644)
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/Mr-Destructive/gh-host/builder"
+	"github.com/Mr-Destructive/gh-host/config"
+)
+
+const reloadScript = `<script>
+new EventSource("/_dev/reload").onmessage = function() {
+	location.reload();
+};
+</script>
+</body>`
+
+// devReloader fans out a reload notification to every connected browser
+// tab whenever a watched source file changes.
+type devReloader struct {
+	mu      sync.Mutex
+	clients map[chan struct{}]struct{}
+}
+
+func newDevReloader() *devReloader {
+	return &devReloader{clients: make(map[chan struct{}]struct{})}
+}
+
+func (d *devReloader) subscribe() chan struct{} {
+	ch := make(chan struct{}, 1)
+	d.mu.Lock()
+	d.clients[ch] = struct{}{}
+	d.mu.Unlock()
+	return ch
+}
+
+func (d *devReloader) unsubscribe(ch chan struct{}) {
+	d.mu.Lock()
+	delete(d.clients, ch)
+	d.mu.Unlock()
+}
+
+func (d *devReloader) broadcast() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for ch := range d.clients {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (d *devReloader) handleSSE(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	ch := d.subscribe()
+	defer d.unsubscribe(ch)
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ch:
+			fmt.Fprint(w, "data: reload\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// liveReloadHandler serves files out of dir and injects the reload
+// snippet into HTML responses just before the closing </body> tag.
+func liveReloadHandler(dir string) http.Handler {
+	fileServer := http.FileServer(http.Dir(dir))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		if path == "/" {
+			path = "/index.html"
+		}
+		if !strings.HasSuffix(path, ".html") {
+			fileServer.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := os.ReadFile(dir + path)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		injected := strings.Replace(string(body), "</body>", reloadScript, 1)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(injected))
+	})
+}
+
+// runDevServer builds the site once, then serves output/ with live reload
+// and rebuilds whenever content/posts or templates change.
+func runDevServer(addr string) error {
+	baseURL := os.Getenv("BASE_URL")
+
+	cfg, err := config.Load("config.yaml")
+	if err != nil {
+		return fmt.Errorf("loading config.yaml: %w", err)
+	}
+
+	if _, err := builder.Build("content/posts", "output", baseURL, cfg); err != nil {
+		return fmt.Errorf("initial build: %w", err)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	for _, dir := range []string{"content/posts", "templates"} {
+		if err := watcher.Add(dir); err != nil {
+			return fmt.Errorf("watching %s: %w", dir, err)
+		}
+	}
+
+	reloader := newDevReloader()
+	go watchAndRebuild(watcher, reloader, baseURL, cfg)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/_dev/reload", reloader.handleSSE)
+	mux.Handle("/", liveReloadHandler("output"))
+
+	srv := &http.Server{Addr: addr, Handler: mux}
+	log.Printf("Dev server listening on %s", addr)
+	return serveWithGracefulShutdown(srv)
+}
+
+// watchAndRebuild incrementally rebuilds the site on every debounced
+// filesystem event and notifies connected browsers once the rebuild
+// succeeds. A change under content/posts only re-renders that one post
+// page (plus the index/tag/feed pages, which need the full post list
+// regardless); any other change (e.g. a template) falls back to a full
+// site rebuild.
+func watchAndRebuild(watcher *fsnotify.Watcher, reloader *devReloader, baseURL string, cfg config.Config) {
+	var rebuildTimer *time.Timer
+
+	rebuild := func(name string) {
+		log.Printf("change detected: %s, rebuilding...", name)
+
+		var err error
+		if slug, ok := postSlugFromPath(name); ok {
+			_, err = builder.BuildIncremental("content/posts", "output", baseURL, cfg, slug)
+		} else {
+			_, err = builder.Build("content/posts", "output", baseURL, cfg)
+		}
+		if err != nil {
+			log.Printf("rebuild failed: %v", err)
+			return
+		}
+		reloader.broadcast()
+	}
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) == 0 {
+				continue
+			}
+
+			if rebuildTimer != nil {
+				rebuildTimer.Stop()
+			}
+			name := event.Name
+			rebuildTimer = time.AfterFunc(100*time.Millisecond, func() {
+				rebuild(name)
+			})
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("watcher error: %v", err)
+		}
+	}
+}
+
+// postSlugFromPath reports the post slug for a changed path under
+// content/posts, so watchAndRebuild can re-render just that page.
+func postSlugFromPath(path string) (string, bool) {
+	path = strings.TrimPrefix(path, "content/posts/")
+	if !strings.HasSuffix(path, ".md") {
+		return "", false
+	}
+	return strings.TrimSuffix(path, ".md"), true
+}
+
+// serveWithGracefulShutdown runs srv until SIGINT/SIGTERM, then drains
+// in-flight requests before returning.
+func serveWithGracefulShutdown(srv *http.Server) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		log.Println("shutting down...")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	}
+}
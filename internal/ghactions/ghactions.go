@@ -0,0 +1,108 @@
+// Package ghactions emits GitHub Actions workflow commands and step
+// summaries, so the CLI and the serve handlers surface richer
+// diagnostics when gh-host runs inside a GitHub Actions job. The
+// workflow-command helpers are no-ops outside Actions
+// (GITHUB_ACTIONS != "true"); SummaryAppend depends only on
+// GITHUB_STEP_SUMMARY being set.
+package ghactions
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Enabled reports whether the process is running inside a GitHub
+// Actions workflow.
+func Enabled() bool {
+	return os.Getenv("GITHUB_ACTIONS") == "true"
+}
+
+// Error emits an ::error:: workflow command. file and line are optional
+// (pass "" and 0 to omit them).
+func Error(file string, line int, message string) {
+	emit("error", file, line, message)
+}
+
+// Warning emits a ::warning:: workflow command.
+func Warning(file string, line int, message string) {
+	emit("warning", file, line, message)
+}
+
+// Notice emits a ::notice:: workflow command.
+func Notice(file string, line int, message string) {
+	emit("notice", file, line, message)
+}
+
+func emit(level, file string, line int, message string) {
+	if !Enabled() {
+		return
+	}
+
+	var params []string
+	if file != "" {
+		params = append(params, "file="+file)
+	}
+	if line > 0 {
+		params = append(params, fmt.Sprintf("line=%d", line))
+	}
+
+	if len(params) == 0 {
+		fmt.Printf("::%s::%s\n", level, escape(message))
+		return
+	}
+
+	fmt.Printf("::%s %s::%s\n", level, strings.Join(params, ","), escape(message))
+}
+
+// Group starts a collapsible log group in the Actions UI. Pair with a
+// deferred call to Endgroup.
+func Group(title string) {
+	if Enabled() {
+		fmt.Printf("::group::%s\n", title)
+	}
+}
+
+// Endgroup closes a group started with Group.
+func Endgroup() {
+	if Enabled() {
+		fmt.Println("::endgroup::")
+	}
+}
+
+// Mask registers value as a secret so the Actions runner redacts it
+// from all subsequent log output.
+func Mask(value string) {
+	if value != "" && Enabled() {
+		fmt.Printf("::add-mask::%s\n", value)
+	}
+}
+
+// SummaryAppend appends markdown to the file named by
+// GITHUB_STEP_SUMMARY. Unlike GITHUB_ENV/GITHUB_OUTPUT, the step summary
+// file takes raw Markdown with no heredoc envelope — its contents are
+// rendered as-is on the job summary page. It is a no-op when
+// GITHUB_STEP_SUMMARY isn't set.
+func SummaryAppend(markdown string) error {
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" {
+		return nil
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintf(file, "%s\n", markdown)
+
+	return err
+}
+
+func escape(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	s = strings.ReplaceAll(s, "\n", "%0A")
+	return s
+}
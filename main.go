@@ -2,18 +2,40 @@ package main
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/urfave/cli/v2"
+
+	"github.com/Mr-Destructive/gh-host/internal/ghactions"
+	"github.com/Mr-Destructive/gh-host/posts"
 )
 
+// maxTimestampSkew bounds how old or how far in the future a signed
+// dispatch-workflow request's X-GH-Host-Timestamp may be before it's
+// rejected as a possible replay.
+const maxTimestampSkew = 5 * time.Minute
+
+// errDispatchSecretNotSet signals that GH_HOST_SECRET isn't configured,
+// a server misconfiguration rather than an authentication failure, so
+// dispatchWorkflowHandler can answer 500 without echoing the detail to
+// the caller.
+var errDispatchSecretNotSet = errors.New("server misconfigured: GH_HOST_SECRET not set")
+
+const contentDir = "content/posts"
+
 func main() {
 	app := &cli.App{
 		Name:  "gh-host",
@@ -44,59 +66,47 @@ func main() {
 				},
 				Action: func(c *cli.Context) error {
 					title := c.String("title")
-					content := c.String("content")
 					tags := c.String("tags")
-					date := c.String("date")
-
-					if date == "" {
-						date = time.Now().Format("2006-01-02")
-					}
-
-					slug := strings.ToLower(strings.ReplaceAll(title, " ", "-"))
-
-					if err := os.MkdirAll("content/posts", 0755); err != nil {
-						return err
-					}
 
-					fileName := fmt.Sprintf("content/posts/%s.md", slug)
-					file, err := os.Create(fileName)
+					slug, err := posts.Create(contentDir, posts.CreateOptions{
+						Title:   title,
+						Content: c.String("content"),
+						Tags:    tags,
+						Date:    c.String("date"),
+					})
 					if err != nil {
+						ghactions.Error("", 0, err.Error())
 						return err
 					}
-					defer file.Close()
-
-					file.WriteString(fmt.Sprintf(`---
-title: %s
-date: %s
-tags: [%s]
----
-
-%s`, title, date, tags, content))
 
-					fmt.Printf("Created post: %s\n", fileName)
+					fmt.Printf("Created post: %s/%s.md\n", contentDir, slug)
+					ghactions.Notice("", 0, fmt.Sprintf("created post %s", slug))
+					reportPostSummary("Created", slug, title, splitTags(tags))
 
 					return nil
 				},
 			},
 			{
-				Name:	"delete",
+				Name:  "delete",
 				Usage: "Delete a post",
 				Flags: []cli.Flag{
 					&cli.StringFlag{
-						Name:	 "slug",
-						Usage:	 "The slug of the post to delete",
+						Name:     "slug",
+						Usage:    "The slug of the post to delete",
 						Required: true,
 					},
 				},
 				Action: func(c *cli.Context) error {
 					slug := c.String("slug")
 
-					fileName := fmt.Sprintf("content/posts/%s.md", slug)
-					if err := os.Remove(fileName); err != nil {
+					if err := posts.Delete(contentDir, slug); err != nil {
+						ghactions.Error("", 0, err.Error())
 						return err
 					}
 
-					fmt.Printf("Deleted post: %s\n", fileName)
+					fmt.Printf("Deleted post: %s/%s.md\n", contentDir, slug)
+					ghactions.Notice("", 0, fmt.Sprintf("deleted post %s", slug))
+					reportPostSummary("Deleted", slug, "", nil)
 
 					return nil
 				},
@@ -116,79 +126,139 @@ tags: [%s]
 					},
 					&cli.StringFlag{
 						Name:  "content",
-						Usage: "The new content of the post in Markdown",
+						Usage: "Replace the post body with this Markdown",
+					},
+					&cli.StringFlag{
+						Name:  "append-content",
+						Usage: "Append this Markdown to the post body",
 					},
 					&cli.StringFlag{
 						Name:  "tags",
 						Usage: "The new comma-separated tags",
 					},
+					&cli.StringFlag{
+						Name:  "date",
+						Usage: "The new date of the post",
+					},
+					&cli.StringSliceFlag{
+						Name:  "set",
+						Usage: "Set an arbitrary frontmatter field, as key=value",
+					},
+					&cli.BoolFlag{
+						Name:  "edit",
+						Usage: "Open $EDITOR on the post instead of applying flags",
+					},
 				},
 				Action: func(c *cli.Context) error {
 					slug := c.String("slug")
-					title := c.String("title")
-					content := c.String("content")
-					tags := c.String("tags")
 
-					fileName := fmt.Sprintf("content/posts/%s.md", slug)
-					file, err := os.ReadFile(fileName)
-					if err != nil {
-						return err
+					opts := posts.UpdateOptions{
+						Title:         c.String("title"),
+						Tags:          c.String("tags"),
+						Date:          c.String("date"),
+						Content:       c.String("content"),
+						AppendContent: c.String("append-content"),
+						Edit:          c.Bool("edit"),
 					}
 
-					lines := strings.Split(string(file), "\n")
-					var newLines []string
-					inFrontmatter := false
-
-					for _, line := range lines {
-						if strings.HasPrefix(line, "---") {
-							inFrontmatter = !inFrontmatter
-							newLines = append(newLines, line)
-							continue
+					for _, kv := range c.StringSlice("set") {
+						key, value, ok := strings.Cut(kv, "=")
+						if !ok {
+							return fmt.Errorf("invalid --set value %q, expected key=value", kv)
 						}
-
-						if inFrontmatter {
-							if strings.HasPrefix(line, "title:") && title != "" {
-								line = fmt.Sprintf("title: %s", title)
-							} else if strings.HasPrefix(line, "tags:") && tags != "" {
-								line = fmt.Sprintf("tags: [%s]", tags)
-							}
-						} else if content != "" {
-							// This will replace the entire content of the file after the frontmatter
-							// A better implementation would be to find the content section and replace it
-							newLines = append(newLines, content)
-							break
+						if opts.Fields == nil {
+							opts.Fields = map[string]string{}
 						}
+						opts.Fields[key] = value
+					}
+
+					if err := posts.Update(contentDir, slug, opts); err != nil {
+						ghactions.Error("", 0, err.Error())
+						return err
+					}
+
+					fmt.Printf("Updated post: %s/%s.md\n", contentDir, slug)
+					ghactions.Notice("", 0, fmt.Sprintf("updated post %s", slug))
 
-						newLines = append(newLines, line)
+					if summary, err := posts.Describe(contentDir, slug); err == nil {
+						reportPostSummary("Updated", slug, summary.Title, summary.Tags)
 					}
 
-					output := strings.Join(newLines, "\n")
-					err = os.WriteFile(fileName, []byte(output), 0644)
+					return nil
+				},
+			},
+			{
+				Name:  "list",
+				Usage: "List posts",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Print as JSON instead of a table",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					summaries, err := posts.List(contentDir)
 					if err != nil {
 						return err
 					}
 
-					fmt.Printf("Updated post: %s\n", fileName)
+					if c.Bool("json") {
+						enc := json.NewEncoder(os.Stdout)
+						enc.SetIndent("", "  ")
+						return enc.Encode(summaries)
+					}
 
-					return nil
+					tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+					fmt.Fprintln(tw, "SLUG\tTITLE\tDATE\tTAGS")
+					for _, s := range summaries {
+						fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", s.Slug, s.Title, s.Date, strings.Join(s.Tags, ","))
+					}
+
+					return tw.Flush()
 				},
 			},
-		},
-	}
+			{
+				Name:  "dev",
+				Usage: "Run a local dev server with live reload and incremental rebuilds",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "addr",
+						Usage: "Address to listen on",
+						Value: ":8000",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					return runDevServer(c.String("addr"))
+				},
+			},
+			{
+				Name:  "serve",
+				Usage: "Start the HTTP server to dispatch workflows",
+				Action: func(c *cli.Context) error {
+					ghactions.Mask(os.Getenv("GH_HOST_SECRET"))
+					ghactions.Mask(os.Getenv("GITHUB_TOKEN"))
 
-	},
-		},
-		{
-			Name:  "serve",
-			Usage: "Start the HTTP server to dispatch workflows",
-			Action: func(c *cli.Context) error {
-				http.HandleFunc("/dispatch-workflow", dispatchWorkflowHandler)
-				port := os.Getenv("PORT")
-				if port == "" {
-					port = "8080"
-				}
-				log.Printf("Server listening on :%s", port)
-				return http.ListenAndServe(":"+port, nil)
+					limiter := newIPRateLimiterFromEnv()
+
+					mux := http.NewServeMux()
+					mux.HandleFunc("/dispatch-workflow", rateLimitMiddleware(limiter, dispatchWorkflowHandler))
+					mux.HandleFunc("/micropub", micropubHandler)
+
+					assets, err := staticAssetHandler("output")
+					if err != nil {
+						return err
+					}
+					mux.Handle("/", assets)
+
+					port := os.Getenv("PORT")
+					if port == "" {
+						port = "8080"
+					}
+
+					srv := &http.Server{Addr: ":" + port, Handler: mux}
+					log.Printf("Server listening on :%s", port)
+					return serveWithGracefulShutdown(srv)
+				},
 			},
 		},
 	}
@@ -198,6 +268,14 @@ tags: [%s]
 	}
 }
 
+// attachment is a file the receiving workflow should commit alongside
+// the post, carried base64-encoded inside the dispatch payload.
+type attachment struct {
+	Name    string `json:"name"`
+	Content string `json:"content"` // base64-encoded
+	Mime    string `json:"mime"`
+}
+
 func dispatchWorkflowHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
@@ -211,71 +289,176 @@ func dispatchWorkflowHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := verifyDispatchSignature(r, body); err != nil {
+		if errors.Is(err, errDispatchSecretNotSet) {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		ghactions.Warning("", 0, "rejected dispatch-workflow request: "+err.Error())
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
 	var data struct {
-		Title    string `json:"title"`
-		Content  string `json:"content"`
-		Tags     string `json:"tags"`
-		Slug     string `json:"slug"`
-		Workflow string `json:"workflow"`
-		Secret   string `json:"secret"`
+		Title       string       `json:"title"`
+		Content     string       `json:"content"`
+		Tags        string       `json:"tags"`
+		Slug        string       `json:"slug"`
+		Workflow    string       `json:"workflow"`
+		Author      string       `json:"author"`
+		Published   string       `json:"published"`
+		Draft       bool         `json:"draft"`
+		Categories  []string     `json:"categories"`
+		Attachments []attachment `json:"attachments"`
 	}
 
-	err = json.Unmarshal(body, &data)
-	if err != nil {
+	if err := json.Unmarshal(body, &data); err != nil {
 		http.Error(w, "Error parsing JSON", http.StatusBadRequest)
 		return
 	}
 
-	// Validate secret
-	expectedSecret := os.Getenv("GH_HOST_SECRET")
-	if expectedSecret == "" {
-		log.Println("GH_HOST_SECRET environment variable not set.")
-		http.Error(w, "Server configuration error: GH_HOST_SECRET not set", http.StatusInternalServerError)
-		return
+	eventType := strings.TrimSuffix(data.Workflow, ".yml") // e.g., "create-post"
+
+	clientPayload := map[string]any{
+		"title":       data.Title,
+		"content":     data.Content,
+		"tags":        data.Tags,
+		"slug":        data.Slug,
+		"author":      data.Author,
+		"published":   data.Published,
+		"draft":       data.Draft,
+		"categories":  data.Categories,
+		"attachments": data.Attachments,
 	}
-	if data.Secret != expectedSecret {
-		http.Error(w, "Invalid secret", http.StatusUnauthorized)
+
+	if err := dispatchRepositoryEvent(eventType, clientPayload); err != nil {
+		ghactions.Error("", 0, err.Error())
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	ghactions.Notice("", 0, fmt.Sprintf("dispatched %s for %s", eventType, data.Slug))
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Workflow triggered successfully!"))
+}
+
+// verifyDispatchSignature checks the X-GH-Host-Signature-256 header
+// against an HMAC-SHA256 of the timestamp-prefixed request body, using
+// GH_HOST_SECRET as the key, modeled on GitHub's own webhook signing.
+// The X-GH-Host-Timestamp header is folded into the signed data so a
+// captured request can't be replayed outside maxTimestampSkew.
+func verifyDispatchSignature(r *http.Request, body []byte) error {
+	secret := os.Getenv("GH_HOST_SECRET")
+	if secret == "" {
+		log.Println("GH_HOST_SECRET environment variable not set.")
+		ghactions.Error("", 0, "GH_HOST_SECRET not set")
+		return errDispatchSecretNotSet
+	}
+
+	timestampHeader := r.Header.Get("X-GH-Host-Timestamp")
+	if timestampHeader == "" {
+		return fmt.Errorf("missing X-GH-Host-Timestamp header")
+	}
+
+	timestamp, err := strconv.ParseInt(timestampHeader, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid X-GH-Host-Timestamp header")
+	}
+
+	skew := time.Since(time.Unix(timestamp, 0))
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > maxTimestampSkew {
+		return fmt.Errorf("request timestamp outside the allowed %s window", maxTimestampSkew)
+	}
+
+	signatureHeader := r.Header.Get("X-GH-Host-Signature-256")
+	signatureHex := strings.TrimPrefix(signatureHeader, "sha256=")
+	if signatureHex == "" {
+		return fmt.Errorf("missing X-GH-Host-Signature-256 header")
+	}
+
+	signature, err := hex.DecodeString(signatureHex)
+	if err != nil {
+		return fmt.Errorf("invalid X-GH-Host-Signature-256 header")
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestampHeader + "."))
+	mac.Write(body)
+
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return fmt.Errorf("signature mismatch")
+	}
+
+	return nil
+}
+
+// reportPostSummary appends a GITHUB_STEP_SUMMARY entry for a
+// create/update/delete CLI action; a no-op when GITHUB_STEP_SUMMARY isn't
+// set.
+func reportPostSummary(action, slug, title string, tags []string) {
+	link := fmt.Sprintf("%s/%s.html", os.Getenv("BASE_URL"), slug)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "### %s post\n\n", action)
+	if title != "" {
+		fmt.Fprintf(&b, "- **Title:** %s\n", title)
+	}
+	fmt.Fprintf(&b, "- **Slug:** %s\n", slug)
+	if len(tags) > 0 {
+		fmt.Fprintf(&b, "- **Tags:** %s\n", strings.Join(tags, ", "))
+	}
+	fmt.Fprintf(&b, "- **Link:** %s\n", link)
+
+	if err := ghactions.SummaryAppend(b.String()); err != nil {
+		log.Printf("writing step summary: %v", err)
+	}
+}
+
+// splitTags turns a comma-separated tags flag into a clean slice,
+// dropping empty entries left by leading/trailing/doubled commas.
+func splitTags(tags string) []string {
+	var out []string
+	for _, tag := range strings.Split(tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			out = append(out, tag)
+		}
+	}
+	return out
+}
+
+// dispatchRepositoryEvent fires a GitHub repository_dispatch event so a
+// workflow in the configured repo can pick up the change, used by both
+// the /dispatch-workflow and /micropub handlers.
+func dispatchRepositoryEvent(eventType string, clientPayload map[string]any) error {
 	githubToken := os.Getenv("GITHUB_TOKEN")
 	if githubToken == "" {
-		log.Println("GITHUB_TOKEN environment variable not set.")
-		http.Error(w, "Server configuration error: GITHUB_TOKEN not set", http.StatusInternalServerError)
-		return
+		return fmt.Errorf("server configuration error: GITHUB_TOKEN not set")
 	}
 
 	repo := os.Getenv("GITHUB_REPOSITORY") // e.g., "owner/repo"
 	if repo == "" {
-		log.Println("GITHUB_REPOSITORY environment variable not set.")
-		http.Error(w, "Server configuration error: GITHUB_REPOSITORY not set", http.StatusInternalServerError)
-		return
+		return fmt.Errorf("server configuration error: GITHUB_REPOSITORY not set")
 	}
 
 	owner := strings.Split(repo, "/")[0]
 	repoName := strings.Split(repo, "/")[1]
 
-	eventType := strings.TrimSuffix(data.Workflow, ".yml") // e.g., "create-post"
-
-	clientPayload := map[string]string{
-		"title":   data.Title,
-		"content": data.Content,
-		"tags":    data.Tags,
-		"slug":    data.Slug,
-		"secret":  data.Secret, // Pass secret for workflow validation if needed
-	}
+	// The dispatches API takes the event type alongside the payload the
+	// receiving workflow reads as github.event.client_payload.
+	clientPayload = map[string]any{"event_type": eventType, "client_payload": clientPayload}
 
 	payloadBytes, err := json.Marshal(clientPayload)
 	if err != nil {
-		http.Error(w, "Error marshalling client payload", http.StatusInternalServerError)
-		return
+		return fmt.Errorf("marshalling client payload: %w", err)
 	}
 
 	githubAPIURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/dispatches", owner, repoName)
 	req, err := http.NewRequest("POST", githubAPIURL, bytes.NewBuffer(payloadBytes))
 	if err != nil {
-		http.Error(w, "Error creating GitHub API request", http.StatusInternalServerError)
-		return
+		return fmt.Errorf("creating GitHub API request: %w", err)
 	}
 
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
@@ -285,18 +468,14 @@ func dispatchWorkflowHandler(w http.ResponseWriter, r *http.Request) {
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		http.Error(w, "Error sending request to GitHub API", http.StatusInternalServerError)
-		return
+		return fmt.Errorf("sending request to GitHub API: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusNoContent {
 		respBody, _ := io.ReadAll(resp.Body)
-		log.Printf("GitHub API error: %d - %s", resp.StatusCode, string(respBody))
-		http.Error(w, fmt.Sprintf("Failed to dispatch workflow: %s", string(respBody)), http.StatusInternalServerError)
-		return
+		return fmt.Errorf("failed to dispatch workflow: %s", string(respBody))
 	}
 
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("Workflow triggered successfully!"))
+	return nil
 }
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/Mr-Destructive/gh-host/internal/ghactions"
+	"github.com/Mr-Destructive/gh-host/posts"
+)
+
+// micropubEntry is the parsed form of a Micropub request, regardless of
+// whether it arrived as application/x-www-form-urlencoded or JSON.
+type micropubEntry struct {
+	Type      string
+	Content   string
+	Name      string
+	Category  []string
+	Published string
+	Slug      string
+	Action    string
+}
+
+// micropubHandler implements a W3C Micropub (https://www.w3.org/TR/micropub/)
+// endpoint for creating, updating and deleting posts from IndieWeb
+// clients, sharing post I/O with the create/update/delete CLI actions.
+func micropubHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	entry, err := parseMicropubRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	action := entry.Action
+	if action == "" {
+		action = "create"
+	}
+
+	if err := verifyIndieAuth(r, action); err != nil {
+		ghactions.Warning("", 0, "rejected micropub request: "+err.Error())
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	switch action {
+	case "create":
+		if entry.Type != "" && entry.Type != "entry" {
+			http.Error(w, "unsupported h-type", http.StatusBadRequest)
+			return
+		}
+
+		slug, err := posts.Create(contentDir, posts.CreateOptions{
+			Title:   entry.Name,
+			Content: entry.Content,
+			Tags:    strings.Join(entry.Category, ","),
+			Date:    entry.Published,
+		})
+		if err != nil {
+			ghactions.Error("", 0, err.Error())
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		dispatchMicropubEvent("create-post", entry, slug)
+		ghactions.Notice("", 0, fmt.Sprintf("micropub created post %s", slug))
+
+		w.Header().Set("Location", postURL(slug))
+		w.WriteHeader(http.StatusCreated)
+	case "update":
+		if entry.Slug == "" || !posts.Exists(contentDir, entry.Slug) {
+			http.Error(w, "post not found", http.StatusNotFound)
+			return
+		}
+
+		err := posts.Update(contentDir, entry.Slug, posts.UpdateOptions{
+			Title:   entry.Name,
+			Content: entry.Content,
+			Tags:    strings.Join(entry.Category, ","),
+		})
+		if err != nil {
+			ghactions.Error("", 0, err.Error())
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		dispatchMicropubEvent("update-post", entry, entry.Slug)
+		ghactions.Notice("", 0, fmt.Sprintf("micropub updated post %s", entry.Slug))
+
+		w.Header().Set("Location", postURL(entry.Slug))
+		w.WriteHeader(http.StatusNoContent)
+	case "delete":
+		if entry.Slug == "" || !posts.ValidSlug(entry.Slug) {
+			http.Error(w, "missing or invalid url", http.StatusBadRequest)
+			return
+		}
+
+		if err := posts.Delete(contentDir, entry.Slug); err != nil {
+			ghactions.Error("", 0, err.Error())
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		dispatchMicropubEvent("delete-post", entry, entry.Slug)
+		ghactions.Notice("", 0, fmt.Sprintf("micropub deleted post %s", entry.Slug))
+
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "unsupported action", http.StatusBadRequest)
+	}
+}
+
+// dispatchMicropubEvent notifies the configured GitHub workflow of a
+// change made through Micropub, the same repository_dispatch mechanism
+// used by /dispatch-workflow, so CI can rebuild and deploy the site. It
+// is best-effort: a dispatch failure is logged, not surfaced, since the
+// post was already written locally.
+func dispatchMicropubEvent(eventType string, entry micropubEntry, slug string) {
+	if os.Getenv("GITHUB_TOKEN") == "" || os.Getenv("GITHUB_REPOSITORY") == "" {
+		return
+	}
+
+	payload := map[string]any{
+		"title":   entry.Name,
+		"content": entry.Content,
+		"tags":    strings.Join(entry.Category, ","),
+		"slug":    slug,
+	}
+
+	if err := dispatchRepositoryEvent(eventType, payload); err != nil {
+		log.Printf("micropub: dispatching %s for %s: %v", eventType, slug, err)
+	}
+}
+
+func postURL(slug string) string {
+	return fmt.Sprintf("%s/%s.html", os.Getenv("BASE_URL"), slug)
+}
+
+// parseMicropubRequest decodes both form-encoded and JSON Micropub
+// create/update/delete requests into a common micropubEntry.
+func parseMicropubRequest(r *http.Request) (micropubEntry, error) {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		return parseMicropubJSON(r)
+	}
+
+	return parseMicropubForm(r)
+}
+
+func parseMicropubForm(r *http.Request) (micropubEntry, error) {
+	if err := r.ParseForm(); err != nil {
+		return micropubEntry{}, err
+	}
+
+	entry := micropubEntry{
+		Type:      strings.TrimPrefix(r.Form.Get("h"), "h-"),
+		Content:   r.Form.Get("content"),
+		Name:      r.Form.Get("name"),
+		Category:  r.Form["category[]"],
+		Published: r.Form.Get("published"),
+		Action:    r.Form.Get("action"),
+	}
+
+	if target := r.Form.Get("url"); target != "" {
+		entry.Slug = slugFromURL(target)
+	}
+
+	return entry, nil
+}
+
+func parseMicropubJSON(r *http.Request) (micropubEntry, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return micropubEntry{}, err
+	}
+
+	var raw struct {
+		Type       []string            `json:"type"`
+		Properties map[string][]string `json:"properties"`
+		Action     string              `json:"action"`
+		URL        string              `json:"url"`
+		Replace    map[string][]string `json:"replace"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return micropubEntry{}, err
+	}
+
+	entry := micropubEntry{Action: raw.Action}
+	if len(raw.Type) > 0 {
+		entry.Type = strings.TrimPrefix(raw.Type[0], "h-")
+	}
+
+	props := raw.Properties
+	if raw.Action == "update" {
+		props = raw.Replace
+	}
+
+	entry.Content = firstValue(props["content"])
+	entry.Name = firstValue(props["name"])
+	entry.Category = props["category"]
+	entry.Published = firstValue(props["published"])
+
+	if raw.URL != "" {
+		entry.Slug = slugFromURL(raw.URL)
+	}
+
+	return entry, nil
+}
+
+func firstValue(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// slugFromURL recovers a post's slug from the URL a Micropub client
+// targets with action=update/delete (e.g. https://host/my-post.html).
+func slugFromURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+
+	return strings.Trim(strings.TrimSuffix(u.Path, ".html"), "/")
+}
+
+// verifyIndieAuth validates the request's Authorization: Bearer token
+// against the configured IndieAuth token endpoint, checking that the
+// returned identity matches ours and that the token's scope covers the
+// requested action.
+func verifyIndieAuth(r *http.Request, requiredScope string) error {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		return fmt.Errorf("missing bearer token")
+	}
+
+	tokenEndpoint := os.Getenv("INDIEAUTH_TOKEN_ENDPOINT")
+	identity := os.Getenv("INDIEAUTH_ME")
+	if tokenEndpoint == "" || identity == "" {
+		return fmt.Errorf("server misconfigured: INDIEAUTH_TOKEN_ENDPOINT/INDIEAUTH_ME not set")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, tokenEndpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("verifying token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("token endpoint returned %d", resp.StatusCode)
+	}
+
+	var verification struct {
+		Me    string `json:"me"`
+		Scope string `json:"scope"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&verification); err != nil {
+		return fmt.Errorf("decoding token verification response: %w", err)
+	}
+
+	if verification.Me != identity {
+		return fmt.Errorf("token is not authorized for %s", identity)
+	}
+
+	if !hasScope(verification.Scope, requiredScope) {
+		return fmt.Errorf("token scope %q does not permit %q", verification.Scope, requiredScope)
+	}
+
+	return nil
+}
+
+// hasScope reports whether space-delimited scope contains the exact
+// token required, rather than merely containing it as a substring
+// (which would let e.g. a token scoped "undelete" satisfy "delete").
+func hasScope(scope, required string) bool {
+	for _, s := range strings.Fields(scope) {
+		if s == required {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,230 @@
+package posts
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ParsedPost is a post split into its frontmatter, kept as a yaml.Node
+// so key order and unrecognized fields survive an edit, and its
+// Markdown body.
+type ParsedPost struct {
+	Frontmatter *yaml.Node
+	Body        string
+}
+
+// ReadFull parses the post at contentDir/slug.md into its frontmatter
+// and body.
+func ReadFull(contentDir, slug string) (*ParsedPost, error) {
+	fileName := fmt.Sprintf("%s/%s.md", contentDir, slug)
+
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	frontmatter, body, err := splitFrontmatter(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(frontmatter), &doc); err != nil {
+		return nil, fmt.Errorf("parsing frontmatter: %w", err)
+	}
+
+	mapping := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	if len(doc.Content) > 0 {
+		mapping = doc.Content[0]
+	}
+
+	return &ParsedPost{Frontmatter: mapping, Body: body}, nil
+}
+
+// WriteFull re-marshals a post's frontmatter and body back to disk.
+func WriteFull(contentDir, slug string, post *ParsedPost) error {
+	fileName := fmt.Sprintf("%s/%s.md", contentDir, slug)
+
+	fmBytes, err := yaml.Marshal(post.Frontmatter)
+	if err != nil {
+		return err
+	}
+
+	content := fmt.Sprintf("---\n%s---\n\n%s", string(fmBytes), post.Body)
+
+	return os.WriteFile(fileName, []byte(content), 0644)
+}
+
+// splitFrontmatter separates a post file's YAML frontmatter from its
+// Markdown body on the `---` delimiters.
+func splitFrontmatter(content string) (frontmatter string, body string, err error) {
+	lines := strings.Split(content, "\n")
+	var fmLines, bodyLines []string
+	count := 0
+	inFrontmatter := false
+
+	for _, line := range lines {
+		if strings.HasPrefix(line, "---") {
+			count++
+			if count == 1 {
+				inFrontmatter = true
+				continue
+			}
+			if count == 2 {
+				inFrontmatter = false
+				continue
+			}
+		}
+
+		if inFrontmatter {
+			fmLines = append(fmLines, line)
+		} else if count >= 2 {
+			bodyLines = append(bodyLines, line)
+		}
+	}
+
+	if count < 2 {
+		return "", "", fmt.Errorf("missing frontmatter delimiters")
+	}
+
+	return strings.Join(fmLines, "\n"), strings.TrimPrefix(strings.Join(bodyLines, "\n"), "\n"), nil
+}
+
+// SetField sets key to value in a frontmatter mapping node as a plain
+// scalar, preserving the position of an existing key or appending a new
+// one at the end.
+func SetField(node *yaml.Node, key, value string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i+1] = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+			return
+		}
+	}
+
+	node.Content = append(node.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value},
+	)
+}
+
+// SetTags replaces the tags field with a flow sequence, keeping the
+// `tags: [a, b]` style the rest of the repo writes.
+func SetTags(node *yaml.Node, tags []string) {
+	seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: yaml.FlowStyle}
+	for _, tag := range tags {
+		seq.Content = append(seq.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: strings.TrimSpace(tag)})
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == "tags" {
+			node.Content[i+1] = seq
+			return
+		}
+	}
+
+	node.Content = append(node.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "tags"},
+		seq,
+	)
+}
+
+// UpdateOptions holds the fields that may be changed on an existing
+// post. Fields holds arbitrary frontmatter keys set via `--set key=value`.
+type UpdateOptions struct {
+	Title         string
+	Tags          string
+	Date          string
+	Content       string
+	AppendContent string
+	Fields        map[string]string
+	Edit          bool
+}
+
+// Update rewrites an existing post's frontmatter and/or body in place.
+// It parses the post into a YAML AST so arbitrary fields and the parts
+// of the body that aren't being replaced survive untouched, instead of
+// matching on raw lines.
+func Update(contentDir, slug string, opts UpdateOptions) error {
+	if !ValidSlug(slug) {
+		return fmt.Errorf("invalid slug %q", slug)
+	}
+
+	if opts.Edit {
+		return editInEditor(contentDir, slug)
+	}
+
+	post, err := ReadFull(contentDir, slug)
+	if err != nil {
+		return err
+	}
+
+	if opts.Title != "" {
+		SetField(post.Frontmatter, "title", opts.Title)
+	}
+	if opts.Tags != "" {
+		SetTags(post.Frontmatter, strings.Split(opts.Tags, ","))
+	}
+	if opts.Date != "" {
+		SetField(post.Frontmatter, "date", opts.Date)
+	}
+	for key, value := range opts.Fields {
+		SetField(post.Frontmatter, key, value)
+	}
+
+	switch {
+	case opts.Content != "":
+		post.Body = opts.Content
+	case opts.AppendContent != "":
+		post.Body = strings.TrimRight(post.Body, "\n") + "\n" + opts.AppendContent
+	}
+
+	return WriteFull(contentDir, slug, post)
+}
+
+// editInEditor opens $EDITOR on a temp file seeded with the post's
+// current contents and overwrites the post with whatever was saved.
+func editInEditor(contentDir, slug string) error {
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		return fmt.Errorf("$EDITOR is not set")
+	}
+
+	fileName := fmt.Sprintf("%s/%s.md", contentDir, slug)
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp("", fmt.Sprintf("gh-host-%s-*.md", slug))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	cmd := exec.Command(editor, tmp.Name())
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("running $EDITOR: %w", err)
+	}
+
+	edited, err := os.ReadFile(tmp.Name())
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(fileName, edited, 0644)
+}
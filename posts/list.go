@@ -0,0 +1,61 @@
+package posts
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+// Summary is the metadata the `list` command and the update step summary
+// need about a post without reading its full body.
+type Summary struct {
+	Slug  string   `json:"slug"`
+	Title string   `json:"title"`
+	Date  string   `json:"date"`
+	Tags  []string `json:"tags"`
+}
+
+// List returns a Summary for every post in contentDir, sorted by slug.
+func List(contentDir string) ([]Summary, error) {
+	files, err := os.ReadDir(contentDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var summaries []Summary
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".md") {
+			continue
+		}
+
+		summary, err := Describe(contentDir, strings.TrimSuffix(file.Name(), ".md"))
+		if err != nil {
+			return nil, err
+		}
+
+		summaries = append(summaries, summary)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Slug < summaries[j].Slug })
+
+	return summaries, nil
+}
+
+// Describe returns the Summary for a single post.
+func Describe(contentDir, slug string) (Summary, error) {
+	post, err := ReadFull(contentDir, slug)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	var fields struct {
+		Title string   `yaml:"title"`
+		Date  string   `yaml:"date"`
+		Tags  []string `yaml:"tags"`
+	}
+	if err := post.Frontmatter.Decode(&fields); err != nil {
+		return Summary{}, err
+	}
+
+	return Summary{Slug: slug, Title: fields.Title, Date: fields.Date, Tags: fields.Tags}, nil
+}
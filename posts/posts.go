@@ -0,0 +1,96 @@
+// Package posts contains the post file I/O shared by the CLI's
+// create/update/delete actions and the Micropub HTTP handler, so both
+// read and write posts the same way.
+package posts
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// slugPattern is the set of characters a slug may contain. Anchoring it
+// to lowercase alphanumerics and hyphens, with no "/" or ".", is what
+// keeps a slug from escaping contentDir when it's used to build a file
+// path.
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// ValidSlug reports whether slug is safe to use as a post's filename.
+// Callers must check this before using a client-supplied slug (e.g. one
+// recovered from a Micropub update/delete URL) in a filesystem path, so
+// a value like "../../etc/passwd" can't escape contentDir.
+func ValidSlug(slug string) bool {
+	return slugPattern.MatchString(slug)
+}
+
+// CreateOptions holds the fields needed to create a new post.
+type CreateOptions struct {
+	Title   string
+	Content string
+	Tags    string
+	Date    string
+}
+
+// Create writes a new post file under contentDir and returns its slug.
+func Create(contentDir string, opts CreateOptions) (string, error) {
+	date := opts.Date
+	if date == "" {
+		date = time.Now().Format("2006-01-02")
+	}
+
+	slug := Slugify(opts.Title)
+
+	if err := os.MkdirAll(contentDir, 0755); err != nil {
+		return "", err
+	}
+
+	fileName := fmt.Sprintf("%s/%s.md", contentDir, slug)
+	file, err := os.Create(fileName)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(fmt.Sprintf(`---
+title: %s
+date: %s
+tags: [%s]
+---
+
+%s`, opts.Title, date, opts.Tags, opts.Content))
+	if err != nil {
+		return "", err
+	}
+
+	return slug, nil
+}
+
+// nonSlugChars matches runs of anything that isn't a lowercase letter
+// or digit, so Slugify can collapse them into a single separator
+// instead of carrying "/" or "." through into a filename.
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Slugify turns a post title into the slug used for its filename and URL.
+func Slugify(title string) string {
+	slug := nonSlugChars.ReplaceAllString(strings.ToLower(title), "-")
+	return strings.Trim(slug, "-")
+}
+
+// Delete removes the post with the given slug from contentDir.
+func Delete(contentDir, slug string) error {
+	if !ValidSlug(slug) {
+		return fmt.Errorf("invalid slug %q", slug)
+	}
+	return os.Remove(fmt.Sprintf("%s/%s.md", contentDir, slug))
+}
+
+// Exists reports whether a post with the given slug exists in contentDir.
+func Exists(contentDir, slug string) bool {
+	if !ValidSlug(slug) {
+		return false
+	}
+	_, err := os.Stat(fmt.Sprintf("%s/%s.md", contentDir, slug))
+	return err == nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ipRateLimiter is a per-source-IP token bucket guarding the webhook
+// handlers from brute-force secret/signature guessing. Rate and burst
+// are configurable via env so deployments can tune them without a
+// rebuild; both default to a conservative rate if unset or invalid.
+type ipRateLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	rate      float64 // tokens per second
+	burst     float64 // bucket capacity
+	lastPrune time.Time
+}
+
+// idleEvictionWindow bounds how long a per-IP bucket may sit idle
+// before Allow reclaims it, so a flood of distinct or rotating source
+// IPs can't grow buckets without bound; a bucket idle this long has
+// long since refilled to burst anyway, so it carries no state worth
+// keeping.
+const idleEvictionWindow = 10 * time.Minute
+
+type tokenBucket struct {
+	tokens   float64
+	lastFill time.Time
+}
+
+// newIPRateLimiterFromEnv builds an ipRateLimiter using RATE_LIMIT_RPS
+// and RATE_LIMIT_BURST, falling back to 1 request/sec with a burst of 5.
+func newIPRateLimiterFromEnv() *ipRateLimiter {
+	rate := 1.0
+	if v := os.Getenv("RATE_LIMIT_RPS"); v != "" {
+		if parsed, err := strconv.ParseFloat(v, 64); err == nil && parsed > 0 {
+			rate = parsed
+		}
+	}
+
+	burst := 5.0
+	if v := os.Getenv("RATE_LIMIT_BURST"); v != "" {
+		if parsed, err := strconv.ParseFloat(v, 64); err == nil && parsed > 0 {
+			burst = parsed
+		}
+	}
+
+	return &ipRateLimiter{
+		buckets:   make(map[string]*tokenBucket),
+		rate:      rate,
+		burst:     burst,
+		lastPrune: time.Now(),
+	}
+}
+
+// Allow reports whether a request from ip may proceed, consuming a
+// token if so.
+func (l *ipRateLimiter) Allow(ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.lastPrune) > idleEvictionWindow {
+		l.prune(now)
+	}
+
+	bucket, ok := l.buckets[ip]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.burst, lastFill: now}
+		l.buckets[ip] = bucket
+	}
+
+	bucket.tokens += now.Sub(bucket.lastFill).Seconds() * l.rate
+	if bucket.tokens > l.burst {
+		bucket.tokens = l.burst
+	}
+	bucket.lastFill = now
+
+	if bucket.tokens < 1 {
+		return false
+	}
+
+	bucket.tokens--
+	return true
+}
+
+// prune discards buckets idle for longer than idleEvictionWindow.
+// Callers must hold l.mu.
+func (l *ipRateLimiter) prune(now time.Time) {
+	for ip, bucket := range l.buckets {
+		if now.Sub(bucket.lastFill) > idleEvictionWindow {
+			delete(l.buckets, ip)
+		}
+	}
+	l.lastPrune = now
+}
+
+// rateLimitMiddleware rejects requests exceeding limiter's per-IP rate
+// with 429 Too Many Requests before calling next.
+func rateLimitMiddleware(limiter *ipRateLimiter, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !limiter.Allow(sourceIP(r)) {
+			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// sourceIP extracts the caller's IP from RemoteAddr, falling back to
+// the raw value if it isn't a host:port pair.
+func sourceIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
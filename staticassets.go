@@ -0,0 +1,26 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/Mr-Destructive/gh-host/builder"
+)
+
+// staticAssetHandler serves the generated site out of outputDir,
+// setting a long-lived immutable Cache-Control on any fingerprinted
+// asset recorded in the build's filemap.json.
+func staticAssetHandler(outputDir string) (http.Handler, error) {
+	fileMap, err := builder.LoadFileMap(outputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	fileServer := http.FileServer(http.Dir(outputDir))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fileMap.IsFingerprinted(r.URL.Path) {
+			w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		}
+		fileServer.ServeHTTP(w, r)
+	}), nil
+}